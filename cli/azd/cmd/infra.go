@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/spf13/cobra"
+)
+
+// NewInfraCmd builds the `azd infra` command group. It's registered onto the root command
+// alongside the other top-level command groups (root.go, outside this package).
+func NewInfraCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "infra",
+		Short: "Manage the infrastructure backing an azd environment",
+	}
+
+	cmd.AddCommand(newInfraDriftCmd())
+	cmd.AddCommand(newInfraProvidersCmd())
+
+	return cmd
+}
+
+// newEnvironment builds the *environment.Environment a subcommand's provider needs out of the
+// `--environment`/`--location`/`--subscription` flags shared by the infra subcommands, mirroring
+// the environment construction TerraformProvider's own tests use.
+func newEnvironment(envName, location, subscriptionID string) *environment.Environment {
+	env := &environment.Environment{Values: map[string]string{}}
+	env.SetEnvName(envName)
+	if location != "" {
+		env.SetLocation(location)
+	}
+	if subscriptionID != "" {
+		env.Values["AZURE_SUBSCRIPTION_ID"] = subscriptionID
+	}
+
+	return env
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/infra"
+	"github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
+	"github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning/terraform"
+	"github.com/spf13/cobra"
+)
+
+// newInfraDriftCmd builds the `azd infra drift` command, which scans the current environment's
+// infrastructure module for drift between the state azd has recorded and what's actually
+// deployed, without making any changes.
+func newInfraDriftCmd() *cobra.Command {
+	var envName, location, subscriptionID, module string
+
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report drift between recorded infrastructure state and what's actually deployed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			env := newEnvironment(envName, location, subscriptionID)
+			provider := terraform.NewTerraformProvider(ctx, env, ".", provisioning.Options{Module: module})
+			scope := infra.NewSubscriptionScope(ctx, location, subscriptionID, envName)
+
+			driftTask := provider.GetDeploymentDrift(ctx, scope)
+
+			done := make(chan struct{})
+			go func() {
+				for progress := range driftTask.Progress() {
+					fmt.Fprintln(cmd.OutOrStdout(), progress.Message)
+				}
+				close(done)
+			}()
+			go func() {
+				for range driftTask.Interactive() {
+				}
+			}()
+
+			result, err := driftTask.Await()
+			<-done
+			if err != nil {
+				return fmt.Errorf("scanning for drift: %w", err)
+			}
+
+			if !result.Drift.HasDrift() {
+				fmt.Fprintln(cmd.OutOrStdout(), "No drift detected.")
+				return nil
+			}
+
+			for _, resource := range result.Drift.Resources {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", resource.Status, resource.Address)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&envName, "environment", "", "the azd environment to scan for drift")
+	cmd.Flags().StringVar(&location, "location", "", "the Azure location the environment is deployed to")
+	cmd.Flags().StringVar(&subscriptionID, "subscription", "", "the Azure subscription the environment is deployed to")
+	cmd.Flags().StringVar(&module, "module", "main", "the infrastructure module name")
+
+	for _, flag := range []string{"environment", "location", "subscription"} {
+		_ = cmd.MarkFlagRequired(flag)
+	}
+
+	return cmd
+}
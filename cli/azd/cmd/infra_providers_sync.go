@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
+	"github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning/terraform"
+	"github.com/spf13/cobra"
+)
+
+// newInfraProvidersCmd builds the `azd infra providers` command group.
+func newInfraProvidersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "providers",
+		Short: "Manage the terraform providers an environment's infrastructure module requires",
+	}
+
+	cmd.AddCommand(newInfraProvidersSyncCmd())
+
+	return cmd
+}
+
+// newInfraProvidersSyncCmd builds the `azd infra providers sync` command, which mirrors the
+// environment's required terraform providers into a local plugin cache (or, with --mirror-dir,
+// an air-gapped install's filesystem mirror) and verifies the mirrored packages.
+func newInfraProvidersSyncCmd() *cobra.Command {
+	var envName, module, mirrorDir string
+	var requiredProviders []string
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Mirror required terraform providers into the local plugin cache",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			env := newEnvironment(envName, "", "")
+			provider := terraform.NewTerraformProvider(ctx, env, ".", provisioning.Options{
+				Module:            module,
+				RequiredProviders: requiredProviders,
+				PluginMirrorDir:   mirrorDir,
+			})
+
+			syncTask := provider.SyncProviders(ctx)
+
+			done := make(chan struct{})
+			go func() {
+				for progress := range syncTask.Progress() {
+					fmt.Fprintln(cmd.OutOrStdout(), progress.Message)
+				}
+				close(done)
+			}()
+			go func() {
+				for range syncTask.Interactive() {
+				}
+			}()
+
+			result, err := syncTask.Await()
+			<-done
+			if err != nil {
+				return fmt.Errorf("syncing providers: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Mirrored %d provider(s) into %s\n", len(result.Providers), result.CacheDir)
+			for _, checksum := range result.Providers {
+				status := "unverified"
+				if checksum.Verified {
+					status = "verified"
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s: %s\n", checksum.Source, status)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&envName, "environment", "", "the azd environment to sync providers for")
+	cmd.Flags().StringVar(&module, "module", "main", "the infrastructure module name")
+	cmd.Flags().StringSliceVar(&requiredProviders, "provider", nil, "a required provider source to mirror (repeatable)")
+	cmd.Flags().StringVar(&mirrorDir, "mirror-dir", "", "directory to mirror providers into for an air-gapped install")
+
+	_ = cmd.MarkFlagRequired("environment")
+	_ = cmd.MarkFlagRequired("provider")
+
+	return cmd
+}
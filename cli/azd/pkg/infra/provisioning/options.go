@@ -0,0 +1,49 @@
+package provisioning
+
+// Options configures how a Provider provisions infrastructure for a project. Fields not
+// relevant to a given Provider are ignored by it.
+type Options struct {
+	// Module is the name of the infrastructure module to run, e.g. "main" for main.tf/main.bicep.
+	Module string
+
+	// PluginCacheDir overrides the directory terraform caches downloaded provider plugins in
+	// (TF_PLUGIN_CACHE_DIR). Defaults to ~/.azd/terraform.d/plugin-cache when unset.
+	PluginCacheDir string
+
+	// PluginMirrorDir, when set, points terraform at a local filesystem mirror of provider
+	// plugins (via a generated .terraformrc) instead of reaching out to the provider registry,
+	// for air-gapped installs. SyncProviders populates this directory.
+	PluginMirrorDir string
+
+	// RequiredProviders lists the provider sources (e.g. "registry.terraform.io/hashicorp/azurerm")
+	// SyncProviders should mirror, verifying each mirrored package against the checksum azd
+	// recorded for it the previous time it was mirrored (not against the module's
+	// .terraform.lock.hcl, whose dirhash format doesn't match the mirrored zip packages).
+	RequiredProviders []string
+
+	// JUnitReportPath, when set, is the path Test writes a JUnit XML report of the module's
+	// `terraform test` results to, for consumption by CI test reporters.
+	JUnitReportPath string
+
+	// StateBackend selects which StateBackend implementation TerraformProvider configures for
+	// the module: "local" (the default), "azurerm" or "remote".
+	StateBackend string
+
+	// StateBackendResourceGroup and StateBackendStorageAccount identify the Azure Storage
+	// account an "azurerm" StateBackend stores state in.
+	StateBackendResourceGroup  string
+	StateBackendStorageAccount string
+
+	// StateBackendSASToken authenticates to the storage account for an "azurerm" StateBackend.
+	// When empty, the storage account's managed identity is used instead.
+	StateBackendSASToken string
+
+	// StateBackendOrganization and StateBackendWorkspace identify the Terraform Cloud/Enterprise
+	// workspace a "remote" StateBackend targets.
+	StateBackendOrganization string
+	StateBackendWorkspace    string
+
+	// StateBackendHostname overrides the Terraform Cloud/Enterprise hostname a "remote"
+	// StateBackend targets, defaulting to app.terraform.io when unset.
+	StateBackendHostname string
+}
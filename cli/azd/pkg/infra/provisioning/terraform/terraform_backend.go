@@ -0,0 +1,120 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+)
+
+// terraformBackend describes the backend block (if any) declared by a terraform module.
+type terraformBackend struct {
+	// kind is the backend name as it appears in the module, e.g. "local", "remote", "cloud".
+	kind string
+
+	// organization and workspace identify the Terraform Cloud/Enterprise workspace that a
+	// "remote" or "cloud" backend targets.
+	organization string
+	workspace    string
+
+	// hostname is the TFE hostname the backend targets. It defaults to app.terraform.io for
+	// Terraform Cloud and is only overridden for Terraform Enterprise installs.
+	hostname string
+}
+
+func (b terraformBackend) isRemote() bool {
+	return b.kind == "remote" || b.kind == "cloud"
+}
+
+var backendBlockPattern = regexp.MustCompile(`backend\s+"(\w+)"`)
+var cloudBlockPattern = regexp.MustCompile(`cloud\s*{`)
+var organizationPattern = regexp.MustCompile(`organization\s*=\s*"([^"]+)"`)
+var workspaceNamePattern = regexp.MustCompile(`name\s*=\s*"([^"]+)"`)
+var hostnamePattern = regexp.MustCompile(`hostname\s*=\s*"([^"]+)"`)
+
+// detectBackend scans the module's terraform files for a `backend` or `cloud` block and
+// extracts the Terraform Cloud/Enterprise workspace it targets, if any. A module with no
+// explicit backend block uses the default local backend.
+func (p *TerraformProvider) detectBackend(ctx context.Context) (terraformBackend, error) {
+	entries, err := os.ReadDir(p.modulePath())
+	if err != nil {
+		return terraformBackend{}, fmt.Errorf("reading module directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".tf" {
+			continue
+		}
+
+		contents, err := os.ReadFile(path.Join(p.modulePath(), entry.Name()))
+		if err != nil {
+			return terraformBackend{}, fmt.Errorf("reading %s: %w", entry.Name(), err)
+		}
+
+		if match := backendBlockPattern.FindSubmatchIndex(contents); match != nil {
+			body := blockBody(contents, match[0])
+			return terraformBackend{
+				kind:         string(contents[match[2]:match[3]]),
+				organization: firstSubmatch(organizationPattern, body),
+				workspace:    firstSubmatch(workspaceNamePattern, body),
+				hostname:     defaultHostname(firstSubmatch(hostnamePattern, body)),
+			}, nil
+		}
+
+		if match := cloudBlockPattern.FindIndex(contents); match != nil {
+			body := blockBody(contents, match[0])
+			return terraformBackend{
+				kind:         "cloud",
+				organization: firstSubmatch(organizationPattern, body),
+				workspace:    firstSubmatch(workspaceNamePattern, body),
+				hostname:     defaultHostname(firstSubmatch(hostnamePattern, body)),
+			}, nil
+		}
+	}
+
+	return terraformBackend{kind: "local"}, nil
+}
+
+// blockBody returns the `{ ... }` body of the block whose header starts at matchStart,
+// tracking brace depth so a nested sub-block (e.g. `workspaces { ... }` inside a `cloud`
+// block) doesn't cause the body to end early. Scoping attribute lookups to this body, rather
+// than searching the whole file, keeps an unrelated `name = "..."` elsewhere in the file (e.g.
+// a resource attribute preceding the backend block) from being mistaken for the block's own.
+func blockBody(contents []byte, matchStart int) []byte {
+	braceStart := bytes.IndexByte(contents[matchStart:], '{')
+	if braceStart == -1 {
+		return nil
+	}
+	braceStart += matchStart
+
+	depth := 0
+	for i := braceStart; i < len(contents); i++ {
+		switch contents[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return contents[braceStart : i+1]
+			}
+		}
+	}
+
+	return contents[braceStart:]
+}
+
+func firstSubmatch(pattern *regexp.Regexp, contents []byte) string {
+	if match := pattern.FindSubmatch(contents); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+func defaultHostname(hostname string) string {
+	if hostname == "" {
+		return "app.terraform.io"
+	}
+	return hostname
+}
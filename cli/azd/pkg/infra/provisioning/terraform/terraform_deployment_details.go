@@ -0,0 +1,19 @@
+package terraform
+
+// TerraformDeploymentDetails is the Provider-specific payload carried on DeploymentPlan.Details
+// for a terraform deployment. It is populated by Plan and consumed by Deploy.
+type TerraformDeploymentDetails struct {
+	ParameterFilePath string
+	PlanFilePath      string
+
+	// localStateFilePath is set when the module uses the default local backend. It is empty
+	// for a remote/cloud backend, where RemoteRunID identifies the run instead.
+	localStateFilePath string
+
+	// RemoteRunID is the Terraform Cloud/Enterprise plan-only run planRemote created for a
+	// module that declares a remote backend, set on the DeploymentPlan Plan returns. It is
+	// empty when the module uses a local backend. Deploy always creates its own (non-plan-only)
+	// run rather than applying this one, since a plan-only run can't itself be applied; it's
+	// carried here only so a caller inspecting the plan can reference what was planned.
+	RemoteRunID string
+}
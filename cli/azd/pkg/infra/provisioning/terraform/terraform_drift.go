@@ -0,0 +1,187 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/async"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/infra"
+	. "github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
+)
+
+// driftExitCodeDetected is the exit code `terraform plan -detailed-exitcode` uses to report that
+// the plan contains changes. For a `-refresh-only` plan this means the real-world state has
+// drifted from what's recorded, rather than that the configuration itself changed.
+const driftExitCodeDetected = 2
+
+// DriftStatus categorizes how a resource's real-world state diverges from the state azd has
+// recorded for it.
+type DriftStatus string
+
+const (
+	// DriftStatusChanged means the resource still exists but one or more of its attributes no
+	// longer match the recorded state.
+	DriftStatusChanged DriftStatus = "Changed"
+	// DriftStatusMissing means the resource is recorded in state but no longer exists.
+	DriftStatusMissing DriftStatus = "Missing"
+	// DriftStatusExtra means the resource exists in the real world but isn't recorded in state.
+	DriftStatusExtra DriftStatus = "Extra"
+)
+
+// DriftedResource describes a single resource whose real-world state diverges from state.
+type DriftedResource struct {
+	Address string
+	Status  DriftStatus
+	Before  map[string]interface{}
+	After   map[string]interface{}
+}
+
+// DriftReport is the result of a GetDeploymentDrift scan, listing every resource whose
+// real-world state diverges from what azd has recorded.
+type DriftReport struct {
+	Resources []DriftedResource
+}
+
+// HasDrift reports whether the scan found any resource drift.
+func (r DriftReport) HasDrift() bool {
+	return len(r.Resources) > 0
+}
+
+// DeploymentDriftResult is the result of GetDeploymentDrift.
+type DeploymentDriftResult struct {
+	Deployment *Deployment
+	Drift      *DriftReport
+}
+
+// GetDeploymentDrift runs `terraform plan -detailed-exitcode -refresh-only` against the module
+// and returns a DriftReport describing how the real-world state diverges from what's recorded,
+// alongside the deployment itself. Exit code 2 from terraform means drift was detected, which is
+// reported through DriftReport rather than as an error; any other non-zero exit is a genuine
+// failure. This backs the `azd infra drift` command.
+func (p *TerraformProvider) GetDeploymentDrift(
+	ctx context.Context,
+	scope infra.Scope,
+) *async.InteractiveTaskWithProgress[*DeploymentDriftResult, bool] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*DeploymentDriftResult, bool]) {
+			if _, _, err := p.runTerraformCommandJSON(ctx, asyncContext, "init"); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetProgress(NewProgressReport("Scanning for drift"))
+			driftPlanPath := p.driftPlanFilePath()
+			_, drifted, err := p.runDriftPlan(ctx, driftPlanPath)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			report := &DriftReport{}
+			if drifted {
+				report, err = p.parseDriftPlan(ctx, driftPlanPath)
+				if err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+			}
+
+			deployment, err := p.createDeployment(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetResult(&DeploymentDriftResult{
+				Deployment: deployment,
+				Drift:      report,
+			})
+		},
+	)
+}
+
+func (p *TerraformProvider) driftPlanFilePath() string {
+	return path.Join(p.envPath(), fmt.Sprintf("%s.drift.tfplan", p.options.Module))
+}
+
+// runDriftPlan runs `terraform plan -refresh-only -detailed-exitcode` and interprets the exit
+// code: 0 means no drift, 2 means drift was detected (and the plan at planFilePath describes
+// it), anything else is a genuine failure.
+func (p *TerraformProvider) runDriftPlan(ctx context.Context, planFilePath string) (executil.RunResult, bool, error) {
+	runArgs, err := p.terraformRunArgs(
+		"plan", "-refresh-only", "-detailed-exitcode", fmt.Sprintf("-out=%s", planFilePath),
+	)
+	if err != nil {
+		return executil.RunResult{}, false, err
+	}
+
+	runResult, err := executil.GetCommandRunner(ctx).Run(ctx, runArgs)
+	if runResult.ExitCode == driftExitCodeDetected {
+		return runResult, true, nil
+	}
+	if err != nil {
+		return runResult, false, fmt.Errorf("terraform plan -refresh-only failed: %w (%s)", err, runResult.Stderr)
+	}
+
+	return runResult, false, nil
+}
+
+// parseDriftPlan runs `terraform show -json <planfile>` against a `-refresh-only` plan and
+// categorizes each proposed resource change as Changed, Missing or Extra drift.
+func (p *TerraformProvider) parseDriftPlan(ctx context.Context, planFilePath string) (*DriftReport, error) {
+	showResult, err := p.runTerraformCommand(ctx, "show", "-json", planFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading drift plan: %w", err)
+	}
+
+	var plan struct {
+		ResourceChanges []struct {
+			Address string `json:"address"`
+			Change  struct {
+				Actions []string               `json:"actions"`
+				Before  map[string]interface{} `json:"before"`
+				After   map[string]interface{} `json:"after"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal([]byte(showResult.Stdout), &plan); err != nil {
+		return nil, fmt.Errorf("parsing drift plan: %w", err)
+	}
+
+	report := &DriftReport{}
+	for _, change := range plan.ResourceChanges {
+		status, ok := driftStatusFromActions(change.Change.Actions)
+		if !ok {
+			continue
+		}
+
+		report.Resources = append(report.Resources, DriftedResource{
+			Address: change.Address,
+			Status:  status,
+			Before:  change.Change.Before,
+			After:   change.Change.After,
+		})
+	}
+
+	return report, nil
+}
+
+// driftStatusFromActions maps the terraform plan `actions` for a resource change to the
+// DriftStatus it represents. A refresh-only plan only ever proposes "update" (state changed in
+// place), "delete" (resource no longer exists) or "create" (resource exists but isn't in state)
+// actions; anything else (e.g. "no-op") isn't drift.
+func driftStatusFromActions(actions []string) (DriftStatus, bool) {
+	switch {
+	case len(actions) == 1 && actions[0] == "update":
+		return DriftStatusChanged, true
+	case len(actions) == 1 && actions[0] == "delete":
+		return DriftStatusMissing, true
+	case len(actions) == 1 && actions[0] == "create":
+		return DriftStatusExtra, true
+	default:
+		return "", false
+	}
+}
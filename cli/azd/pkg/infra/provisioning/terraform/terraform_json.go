@@ -0,0 +1,175 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	. "github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
+)
+
+// ResourceProgress is a single per-resource status update parsed out of a `terraform <cmd>
+// -json` message stream. runTerraformCommandJSON returns these values to its caller; Progress()
+// carries each one only as a textual "<action>: <address>" report, not as a typed value.
+type ResourceProgress struct {
+	Address string
+	Action  string
+	Elapsed time.Duration
+}
+
+// DiagnosticError wraps a `diagnostic` message from a `terraform <cmd> -json` stream so callers
+// can inspect the severity, summary and source range of a failure rather than matching on the
+// rendered error text.
+type DiagnosticError struct {
+	Severity string
+	Summary  string
+	Detail   string
+	Range    string
+}
+
+func (e *DiagnosticError) Error() string {
+	if e.Range != "" {
+		return fmt.Sprintf("terraform %s: %s (%s)", e.Severity, e.Summary, e.Range)
+	}
+	return fmt.Sprintf("terraform %s: %s", e.Severity, e.Summary)
+}
+
+// tfJSONMessage is a single line of a `terraform <cmd> -json` message stream. Only the fields
+// azd needs to drive Progress() and surface diagnostics are modelled; the rest of the message is
+// ignored.
+type tfJSONMessage struct {
+	Type string `json:"type"`
+
+	Hook *struct {
+		Resource struct {
+			Addr string `json:"addr"`
+		} `json:"resource"`
+		Action         string  `json:"action"`
+		ElapsedSeconds float64 `json:"elapsed_seconds"`
+	} `json:"hook,omitempty"`
+
+	Change *struct {
+		Resource struct {
+			Addr string `json:"addr"`
+		} `json:"resource"`
+		Action string `json:"action"`
+	} `json:"change,omitempty"`
+
+	Diagnostic *struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+		Detail   string `json:"detail"`
+		Range    *struct {
+			Filename string `json:"filename"`
+			Start    struct {
+				Line int `json:"line"`
+			} `json:"start"`
+		} `json:"range,omitempty"`
+	} `json:"diagnostic,omitempty"`
+}
+
+// runTerraformCommandJSON runs a terraform subcommand with `-json` appended and parses the
+// newline-delimited JSON message stream it produces. It reports a textual progress entry for
+// every resource-level message (see resourceProgressFromMessages) and, if any `diagnostic`
+// message carries "error" severity, returns the first one as a *DiagnosticError instead of the
+// generic "terraform ... failed" error runTerraformCommand would otherwise produce.
+func (p *TerraformProvider) runTerraformCommandJSON(
+	ctx context.Context,
+	asyncContext interface{ SetProgress(report ProgressReport) },
+	args ...string,
+) (executil.RunResult, []ResourceProgress, error) {
+	runResult, runErr := p.runTerraformCommand(ctx, append(args, "-json")...)
+
+	messages := parseTerraformJSONStream(runResult.Stdout)
+
+	if diagErr := firstErrorDiagnostic(messages); diagErr != nil {
+		return runResult, nil, diagErr
+	}
+	if runErr != nil {
+		return runResult, nil, runErr
+	}
+
+	resourceProgress := resourceProgressFromMessages(messages)
+	for _, progress := range resourceProgress {
+		asyncContext.SetProgress(NewProgressReport(fmt.Sprintf("%s: %s", progress.Action, progress.Address)))
+	}
+
+	return runResult, resourceProgress, nil
+}
+
+// resourceProgressFromMessages extracts the per-resource progress events out of a parsed
+// `terraform <cmd> -json` message stream, in the order they were emitted.
+func resourceProgressFromMessages(messages []tfJSONMessage) []ResourceProgress {
+	var progress []ResourceProgress
+
+	for _, message := range messages {
+		switch message.Type {
+		case "planned_change", "resource_drift":
+			if message.Change != nil {
+				progress = append(progress, ResourceProgress{
+					Address: message.Change.Resource.Addr,
+					Action:  message.Change.Action,
+				})
+			}
+		case "apply_start", "apply_progress", "apply_complete":
+			if message.Hook != nil {
+				progress = append(progress, ResourceProgress{
+					Address: message.Hook.Resource.Addr,
+					Action:  message.Hook.Action,
+					Elapsed: time.Duration(message.Hook.ElapsedSeconds * float64(time.Second)),
+				})
+			}
+		}
+	}
+
+	return progress
+}
+
+// firstErrorDiagnostic returns the first "error" severity diagnostic in messages, or nil if
+// there isn't one.
+func firstErrorDiagnostic(messages []tfJSONMessage) *DiagnosticError {
+	for _, message := range messages {
+		if message.Type != "diagnostic" || message.Diagnostic == nil || message.Diagnostic.Severity != "error" {
+			continue
+		}
+
+		diagErr := &DiagnosticError{
+			Severity: message.Diagnostic.Severity,
+			Summary:  message.Diagnostic.Summary,
+			Detail:   message.Diagnostic.Detail,
+		}
+		if message.Diagnostic.Range != nil {
+			diagErr.Range = fmt.Sprintf("%s:%d", message.Diagnostic.Range.Filename, message.Diagnostic.Range.Start.Line)
+		}
+		return diagErr
+	}
+
+	return nil
+}
+
+// parseTerraformJSONStream parses the newline-delimited JSON produced by `terraform <cmd>
+// -json`, skipping blank lines. A line that fails to parse as JSON is ignored rather than
+// failing the whole stream, since terraform can interleave plain text (e.g. provider plugin
+// output) with JSON messages.
+func parseTerraformJSONStream(output string) []tfJSONMessage {
+	var messages []tfJSONMessage
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var message tfJSONMessage
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			continue
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages
+}
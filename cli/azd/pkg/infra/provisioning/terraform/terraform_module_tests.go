@@ -0,0 +1,250 @@
+package terraform
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/async"
+)
+
+// TestResult is the outcome of running a module's `*.tftest.hcl` test files.
+type TestResult struct {
+	Files []TestFileResult
+}
+
+// Passed reports whether every run in every file passed.
+func (r TestResult) Passed() bool {
+	for _, file := range r.Files {
+		for _, run := range file.Runs {
+			if run.Status != "pass" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// TestFileResult is the outcome of running a single `*.tftest.hcl` file.
+type TestFileResult struct {
+	Path string
+	Runs []TestRunResult
+}
+
+// TestRunResult is the outcome of a single `run` block within a test file.
+type TestRunResult struct {
+	Name        string
+	Status      string // "pass", "fail" or "skip"
+	Diagnostics []TestDiagnostic
+}
+
+// TestDiagnostic describes a failed assertion surfaced by `terraform test`.
+type TestDiagnostic struct {
+	Summary         string
+	Message         string
+	ResourceAddress string
+}
+
+var runResultPattern = regexp.MustCompile(`^run "([^"]+)"\.\.\. (pass|fail|skip)$`)
+var assertionPattern = regexp.MustCompile(`^\s*Error: (.+)$`)
+
+// resourceRefPattern matches the resource reference line `terraform test` prints underneath a
+// failed assertion's summary, e.g. `  azurerm_resource_group.rg.location: "eastus"`, capturing
+// just the `<type>.<name>` resource address and discarding the trailing attribute path.
+var resourceRefPattern = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*\.[a-zA-Z0-9_-]+)(?:\.[a-zA-Z0-9_\[\]"-]+)*\s*:`)
+
+// Test discovers `*.tftest.hcl` files under the module directory, runs `terraform test`
+// against each and reports the pass/fail outcome of every `run` block through Progress().
+// When Options.JUnitReportPath is set, the aggregated result is also written out as a JUnit
+// XML report so the results can be consumed by CI.
+func (p *TerraformProvider) Test(ctx context.Context) *async.InteractiveTaskWithProgress[*TestResult, bool] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*TestResult, bool]) {
+			testFiles, err := p.discoverTestFiles()
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			result := &TestResult{}
+
+			for _, testFile := range testFiles {
+				asyncContext.SetProgress(NewProgressReport(fmt.Sprintf("Running terraform test %s", testFile)))
+
+				fileResult, err := p.runTestFile(ctx, testFile)
+				if err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+
+				for _, run := range fileResult.Runs {
+					asyncContext.SetProgress(NewProgressReport(fmt.Sprintf("%s: %s", run.Name, run.Status)))
+				}
+
+				result.Files = append(result.Files, fileResult)
+			}
+
+			if p.options.JUnitReportPath != "" {
+				asyncContext.SetProgress(NewProgressReport("Writing JUnit test report"))
+				if err := writeJUnitReport(p.options.JUnitReportPath, *result); err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+			}
+
+			asyncContext.SetResult(result)
+		},
+	)
+}
+
+// discoverTestFiles returns the paths, relative to the module directory, of every
+// `*.tftest.hcl` file the module declares.
+func (p *TerraformProvider) discoverTestFiles() ([]string, error) {
+	var testFiles []string
+
+	err := filepath.WalkDir(p.modulePath(), func(filePath string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(d.Name(), ".tftest.hcl") {
+			relPath, err := filepath.Rel(p.modulePath(), filePath)
+			if err != nil {
+				return err
+			}
+			testFiles = append(testFiles, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discovering terraform test files: %w", err)
+	}
+
+	return testFiles, nil
+}
+
+// runTestFile runs `terraform test` for a single test file and parses the per-run outcomes
+// out of its (human-readable) output.
+func (p *TerraformProvider) runTestFile(ctx context.Context, testFile string) (TestFileResult, error) {
+	// terraform test returns a non-zero exit code when any run fails; the per-run detail is in
+	// stdout regardless, so ignore the error here and parse what was produced.
+	runResult, _ := p.runTerraformCommand(ctx, "test", fmt.Sprintf("-filter=%s", testFile))
+
+	return TestFileResult{
+		Path: testFile,
+		Runs: parseTestOutput(runResult.Stdout),
+	}, nil
+}
+
+func parseTestOutput(output string) []TestRunResult {
+	var runs []TestRunResult
+	var current *TestRunResult
+
+	for _, line := range strings.Split(output, "\n") {
+		if match := runResultPattern.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			if current != nil {
+				runs = append(runs, *current)
+			}
+			current = &TestRunResult{Name: match[1], Status: match[2]}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if match := assertionPattern.FindStringSubmatch(line); match != nil {
+			current.Diagnostics = append(current.Diagnostics, TestDiagnostic{
+				Summary: match[1],
+				Message: strings.TrimSpace(line),
+			})
+			continue
+		}
+
+		// A resource reference line belongs to the diagnostic most recently appended above; it
+		// always follows that diagnostic's `Error:` line and precedes the next one.
+		if len(current.Diagnostics) > 0 {
+			if match := resourceRefPattern.FindStringSubmatch(line); match != nil {
+				current.Diagnostics[len(current.Diagnostics)-1].ResourceAddress = match[1]
+			}
+		}
+	}
+
+	if current != nil {
+		runs = append(runs, *current)
+	}
+
+	return runs
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Detail  string `xml:",chardata"`
+}
+
+// writeJUnitReport renders a TestResult as a JUnit XML report so it can be picked up by CI
+// systems that understand the format.
+func writeJUnitReport(path string, result TestResult) error {
+	suites := junitTestSuites{}
+
+	for _, file := range result.Files {
+		suite := junitTestSuite{Name: file.Path, Tests: len(file.Runs)}
+
+		for _, run := range file.Runs {
+			testCase := junitTestCase{Name: run.Name}
+
+			if run.Status == "fail" {
+				suite.Failures++
+
+				var detail strings.Builder
+				for _, diagnostic := range run.Diagnostics {
+					detail.WriteString(diagnostic.Message)
+					detail.WriteString("\n")
+				}
+
+				testCase.Failure = &junitFailure{
+					Message: "assertion failed",
+					Detail:  detail.String(),
+				}
+			}
+
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	bytes, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling JUnit report: %w", err)
+	}
+
+	if err := os.WriteFile(path, bytes, 0644); err != nil {
+		return fmt.Errorf("writing JUnit report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,410 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/async"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/infra"
+	. "github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+)
+
+// TerraformProvider implements Provider in terms of the terraform CLI. It shells out to
+// `terraform` for the init/validate/plan/apply/destroy lifecycle and, when the module
+// declares a remote backend, delegates the run to the remote workspace instead.
+type TerraformProvider struct {
+	env         *environment.Environment
+	projectPath string
+	options     Options
+	console     input.Console
+
+	// pluginInstallOnce guards the one-time setup (plugin cache directory, .terraformrc) that
+	// configurePluginInstallation performs; see terraform_plugin_cache.go.
+	pluginInstallOnce sync.Once
+	pluginInstallEnv  []string
+	pluginInstallErr  error
+}
+
+// NewTerraformProvider creates a new TerraformProvider for the given project and module options.
+func NewTerraformProvider(
+	ctx context.Context,
+	env *environment.Environment,
+	projectPath string,
+	options Options,
+) *TerraformProvider {
+	return &TerraformProvider{
+		env:         env,
+		projectPath: projectPath,
+		options:     options,
+		console:     input.GetConsole(ctx),
+	}
+}
+
+// Name implements Provider.
+func (p *TerraformProvider) Name() string {
+	return "Terraform"
+}
+
+// modulePath returns the directory containing the terraform module for this project.
+func (p *TerraformProvider) modulePath() string {
+	return path.Join(p.projectPath, "infra")
+}
+
+// envPath returns the directory that azd uses to store environment-scoped terraform artifacts.
+func (p *TerraformProvider) envPath() string {
+	return path.Join(p.projectPath, ".azure", p.env.GetEnvName())
+}
+
+func (p *TerraformProvider) parameterFilePath() string {
+	return path.Join(p.envPath(), fmt.Sprintf("%s.tfvars.json", p.options.Module))
+}
+
+func (p *TerraformProvider) planFilePath() string {
+	return path.Join(p.envPath(), fmt.Sprintf("%s.tfplan", p.options.Module))
+}
+
+func (p *TerraformProvider) localStateFilePath() string {
+	return path.Join(p.envPath(), "terraform.tfstate")
+}
+
+// terraformRunArgs builds the executil.RunArgs for `terraform -chdir=<module> <args...>`, with
+// the plugin cache (and, for an air-gapped install, the filesystem mirror) configured via
+// environment variables. Used by both runTerraformCommand and runDriftPlan so every terraform
+// invocation shares the same environment setup.
+func (p *TerraformProvider) terraformRunArgs(args ...string) (executil.RunArgs, error) {
+	env, err := p.configurePluginInstallation()
+	if err != nil {
+		return executil.RunArgs{}, err
+	}
+
+	return executil.RunArgs{
+		Cmd:  "terraform",
+		Args: append([]string{fmt.Sprintf("-chdir=%s", p.modulePath())}, args...),
+		Env:  env,
+	}, nil
+}
+
+// runTerraformCommand runs `terraform -chdir=<module> <args...>` against the module directory
+// and returns the raw result, surfacing any failure as an error that includes the command's
+// stderr.
+func (p *TerraformProvider) runTerraformCommand(
+	ctx context.Context,
+	args ...string,
+) (executil.RunResult, error) {
+	runArgs, err := p.terraformRunArgs(args...)
+	if err != nil {
+		return executil.RunResult{}, err
+	}
+
+	runResult, err := executil.GetCommandRunner(ctx).Run(ctx, runArgs)
+	if err != nil {
+		return runResult, fmt.Errorf("terraform %s failed: %w (%s)", strings.Join(args, " "), err, runResult.Stderr)
+	}
+
+	return runResult, nil
+}
+
+// RequiredExternalTools implements Provider.
+func (p *TerraformProvider) RequiredExternalTools() []string {
+	return []string{"terraform"}
+}
+
+// Plan implements Provider. It initializes the module, writes the tfvars parameter file,
+// validates the module and produces a plan, returning a DeploymentPlan that Deploy can apply.
+// When the module declares a remote backend, the plan is produced by a speculative run in the
+// remote workspace instead (see planRemote), since Terraform Cloud's remote execution mode
+// doesn't support writing out a local plan file.
+func (p *TerraformProvider) Plan(ctx context.Context) *async.InteractiveTaskWithProgress[*DeploymentPlan, bool] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*DeploymentPlan, bool]) {
+			backend, err := p.detectBackend(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			if backend.isRemote() {
+				plan, err := p.planRemote(ctx, asyncContext, backend)
+				if err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+				asyncContext.SetResult(plan)
+				return
+			}
+
+			asyncContext.SetProgress(NewProgressReport("Initialize terraform"))
+			if err := p.configureStateBackend(ctx, asyncContext); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetProgress(NewProgressReport("Generating terraform parameters"))
+			if err := p.writeParameters(); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetProgress(NewProgressReport("Validate terraform template"))
+			validateResult, err := p.runTerraformCommand(ctx, "validate")
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+			asyncContext.SetProgress(
+				NewProgressReport(fmt.Sprintf("terraform validate result : %s", validateResult.Stdout)),
+			)
+
+			asyncContext.SetProgress(NewProgressReport("Plan terraform template"))
+			_, _, err = p.runTerraformCommandJSON(
+				ctx,
+				asyncContext,
+				"plan", fmt.Sprintf("-var-file=%s", p.parameterFilePath()), fmt.Sprintf("-out=%s", p.planFilePath()),
+			)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetProgress(NewProgressReport("Create terraform template"))
+			deployment, err := p.createDeployment(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetResult(&DeploymentPlan{
+				Deployment: deployment,
+				Details: TerraformDeploymentDetails{
+					ParameterFilePath:  p.parameterFilePath(),
+					PlanFilePath:       p.planFilePath(),
+					localStateFilePath: p.localStateFilePath(),
+				},
+			})
+		},
+	)
+}
+
+// Deploy implements Provider. For a local backend this runs `terraform apply` against the
+// plan produced by Plan. When the module declares a remote backend, the run is delegated to
+// the remote workspace instead (see deployRemote).
+func (p *TerraformProvider) Deploy(
+	ctx context.Context,
+	deploymentPlan *DeploymentPlan,
+	scope infra.Scope,
+) *async.InteractiveTaskWithProgress[*DeployResult, bool] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*DeployResult, bool]) {
+			details := deploymentPlan.Details.(TerraformDeploymentDetails)
+
+			backend, err := p.detectBackend(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			if backend.isRemote() {
+				result, err := p.deployRemote(ctx, asyncContext, details, backend)
+				if err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+				asyncContext.SetResult(result)
+				return
+			}
+
+			asyncContext.SetInteractive(true)
+			if confirmed, err := p.console.Confirm(ctx, input.ConsoleOptions{
+				Message: "Do you want to apply the terraform plan?",
+			}); err != nil {
+				asyncContext.SetError(err)
+				return
+			} else if !confirmed {
+				asyncContext.SetError(fmt.Errorf("apply cancelled"))
+				return
+			}
+			asyncContext.SetInteractive(false)
+
+			if _, _, err := p.runTerraformCommandJSON(ctx, asyncContext, "apply", "-auto-approve", details.PlanFilePath); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			deployment, err := p.createDeployment(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetResult(&DeployResult{Deployment: deployment})
+		},
+	)
+}
+
+// Destroy implements Provider.
+func (p *TerraformProvider) Destroy(
+	ctx context.Context,
+	deployment *Deployment,
+	options DestroyOptions,
+) *async.InteractiveTaskWithProgress[*DestroyResult, bool] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*DestroyResult, bool]) {
+			if _, _, err := p.runTerraformCommandJSON(ctx, asyncContext, "init"); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			backend, err := p.detectBackend(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			if backend.isRemote() {
+				outputs, err := p.destroyRemote(ctx, asyncContext, backend, options.Force())
+				if err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+				asyncContext.SetResult(&DestroyResult{Outputs: outputs})
+				return
+			}
+
+			outputs, err := p.loadOutputs(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			destroyArgs := []string{"destroy"}
+			if options.Force() {
+				destroyArgs = append(destroyArgs, "-auto-approve")
+			}
+			if _, _, err := p.runTerraformCommandJSON(ctx, asyncContext, destroyArgs...); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetResult(&DestroyResult{Outputs: outputs})
+		},
+	)
+}
+
+// GetDeployment implements Provider. When the module declares a remote backend, the outputs are
+// read directly from the remote workspace instead of running `terraform init`/`output` locally.
+func (p *TerraformProvider) GetDeployment(
+	ctx context.Context,
+	scope infra.Scope,
+) *async.InteractiveTaskWithProgress[*DeployResult, bool] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*DeployResult, bool]) {
+			backend, err := p.detectBackend(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			if backend.isRemote() {
+				client := newTFEClient(backend)
+				workspaceID, err := resolveWorkspaceID(ctx, client, backend.organization, backend.workspace)
+				if err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+
+				outputs, err := p.fetchWorkspaceOutputs(ctx, client, workspaceID)
+				if err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+
+				asyncContext.SetResult(&DeployResult{Deployment: &Deployment{Outputs: outputs}})
+				return
+			}
+
+			if _, _, err := p.runTerraformCommandJSON(ctx, asyncContext, "init"); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			deployment, err := p.createDeployment(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetResult(&DeployResult{Deployment: deployment})
+		},
+	)
+}
+
+func (p *TerraformProvider) writeParameters() error {
+	parameters := map[string]interface{}{
+		"location": p.env.Values["AZURE_LOCATION"],
+		"name":     p.env.Values["AZURE_ENV_NAME"],
+	}
+
+	bytes, err := json.MarshalIndent(parameters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling terraform parameters: %w", err)
+	}
+
+	if err := os.MkdirAll(p.envPath(), 0755); err != nil {
+		return fmt.Errorf("creating environment directory: %w", err)
+	}
+
+	if err := os.WriteFile(p.parameterFilePath(), bytes, 0644); err != nil {
+		return fmt.Errorf("writing terraform parameters: %w", err)
+	}
+
+	return nil
+}
+
+func (p *TerraformProvider) createDeployment(ctx context.Context) (*Deployment, error) {
+	outputs, err := p.loadOutputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Deployment{
+		Parameters: map[string]InputParameter{
+			"location": {Value: p.env.Values["AZURE_LOCATION"]},
+			"name":     {Value: p.env.Values["AZURE_ENV_NAME"]},
+		},
+		Outputs: outputs,
+	}, nil
+}
+
+func (p *TerraformProvider) loadOutputs(ctx context.Context) (map[string]OutputParameter, error) {
+	outputResult, err := p.runTerraformCommand(ctx, "output", "-json")
+	if err != nil {
+		return nil, err
+	}
+
+	var rawOutputs map[string]struct {
+		Sensitive bool        `json:"sensitive"`
+		Type      string      `json:"type"`
+		Value     interface{} `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(outputResult.Stdout), &rawOutputs); err != nil {
+		return nil, fmt.Errorf("parsing terraform output: %w", err)
+	}
+
+	outputs := make(map[string]OutputParameter, len(rawOutputs))
+	for key, value := range rawOutputs {
+		outputs[key] = OutputParameter{
+			Type:  value.Type,
+			Value: value.Value,
+		}
+	}
+
+	return outputs, nil
+}
@@ -3,6 +3,8 @@ package terraform
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
 	"path"
 	"strings"
 	"testing"
@@ -13,6 +15,7 @@ import (
 	. "github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
 	"github.com/azure/azure-dev/cli/azd/test/mocks"
 	execmock "github.com/azure/azure-dev/cli/azd/test/mocks/executil"
+	mockhttp "github.com/azure/azure-dev/cli/azd/test/mocks/mockhttp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -53,7 +56,7 @@ func TestTerraformPlan(t *testing.T) {
 	require.Contains(t, progressLog[2], "Validate terraform template")
 	require.Contains(t, progressLog[3], "terraform validate result : Success! The configuration is valid.")
 	require.Contains(t, progressLog[4], "Plan terraform template")
-	require.Contains(t, progressLog[5], "terraform plan result : To perform exactly these actions, run the following command to apply:terraform apply")
+	require.Contains(t, progressLog[5], "create: azurerm_resource_group.rg")
 	require.Contains(t, progressLog[6], "Create terraform template")
 
 	require.Equal(t, infraProvider.env.Values["AZURE_LOCATION"], deploymentPlan.Deployment.Parameters["location"].Value)
@@ -69,6 +72,185 @@ func TestTerraformPlan(t *testing.T) {
 	require.NotEmpty(t, terraformDeploymentData.localStateFilePath)
 }
 
+// TestTerraformPlanPluginCache covers that the `terraform init` invocation sets TF_PLUGIN_CACHE_DIR
+// so downloaded provider plugins are shared across environments/modules instead of being
+// re-fetched each time.
+func TestTerraformPlanPluginCache(t *testing.T) {
+	var sawPluginCacheEnv bool
+
+	mockContext := mocks.NewMockContext(context.Background())
+	prepareGenericMocks(mockContext.CommandRunner)
+
+	modulePath := "..\\..\\..\\..\\test\\samples\\resourcegroupterraform\\infra"
+	mockContext.CommandRunner.When(func(args executil.RunArgs, command string) bool {
+		if !strings.Contains(command, fmt.Sprintf("terraform -chdir=%s init", modulePath)) {
+			return false
+		}
+		for _, env := range args.Env {
+			if strings.HasPrefix(env, "TF_PLUGIN_CACHE_DIR=") {
+				sawPluginCacheEnv = true
+			}
+		}
+		return true
+	}).Respond(executil.RunResult{
+		Stdout: "Terraform has been successfully initialized!",
+		Stderr: "",
+	})
+
+	mockContext.CommandRunner.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s validate", modulePath))
+	}).Respond(executil.RunResult{
+		Stdout: "Success! The configuration is valid.",
+		Stderr: "",
+	})
+
+	mockContext.CommandRunner.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s plan", modulePath))
+	}).Respond(executil.RunResult{
+		Stdout: `{"type":"planned_change","change":{"resource":{"addr":"azurerm_resource_group.rg"},"action":"create"}}`,
+		Stderr: "",
+	})
+
+	mockContext.CommandRunner.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s output", modulePath))
+	}).Respond(executil.RunResult{
+		Stdout: `{"AZURE_LOCATION": {"sensitive": false,"type": "string","value": "westus2"},"RG_NAME":{"sensitive": false,"type": "string","value": "rg-test-env"}}`,
+		Stderr: "",
+	})
+
+	infraProvider := createTerraformProvider(*mockContext.Context)
+	planningTask := infraProvider.Plan(*mockContext.Context)
+
+	go func() {
+		for range planningTask.Progress() {
+		}
+	}()
+	go func() {
+		for range planningTask.Interactive() {
+		}
+	}()
+
+	_, err := planningTask.Await()
+
+	require.Nil(t, err)
+	require.True(t, sawPluginCacheEnv)
+}
+
+// TestTerraformPlanDiagnostic covers surfacing an "error" severity `diagnostic` message from
+// `terraform plan -json` as a *DiagnosticError, rather than the generic "terraform ... failed"
+// error produced when terraform itself exits non-zero.
+func TestTerraformPlanDiagnostic(t *testing.T) {
+	mockContext := mocks.NewMockContext(context.Background())
+	prepareGenericMocks(mockContext.CommandRunner)
+	preparePlanningMocks(mockContext.CommandRunner)
+
+	modulePath := "..\\..\\..\\..\\test\\samples\\resourcegroupterraform\\infra"
+	mockContext.CommandRunner.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s plan", modulePath))
+	}).Respond(executil.RunResult{
+		Stdout: `{"type":"diagnostic","diagnostic":{"severity":"error","summary":"Unsupported argument","detail":"An argument named \"locaton\" is not expected here.","range":{"filename":"main.tf","start":{"line":12}}}}`,
+		Stderr: "",
+	})
+
+	infraProvider := createTerraformProvider(*mockContext.Context)
+	planningTask := infraProvider.Plan(*mockContext.Context)
+
+	go func() {
+		for range planningTask.Progress() {
+		}
+	}()
+	go func() {
+		for range planningTask.Interactive() {
+		}
+	}()
+
+	_, err := planningTask.Await()
+
+	require.Error(t, err)
+	var diagErr *DiagnosticError
+	require.ErrorAs(t, err, &diagErr)
+	require.Equal(t, "error", diagErr.Severity)
+	require.Equal(t, "Unsupported argument", diagErr.Summary)
+	require.Equal(t, "main.tf:12", diagErr.Range)
+}
+
+// TestTerraformPlanAzurermBackend covers Plan selecting the azurerm StateBackend: it should
+// create the state container via the az CLI, write a backend_override.tf.json selecting the
+// azurerm backend, and re-init with -reconfigure.
+func TestTerraformPlanAzurermBackend(t *testing.T) {
+	mockContext := mocks.NewMockContext(context.Background())
+	prepareGenericMocks(mockContext.CommandRunner)
+	preparePlanningMocks(mockContext.CommandRunner)
+	prepareAzurermBackendMocks(mockContext.CommandRunner)
+
+	projectDir := "../../../../test/samples/resourcegroupterraform"
+	options := Options{
+		Module:                     "main",
+		StateBackend:               "azurerm",
+		StateBackendStorageAccount: "sttfstate",
+	}
+	env := environment.Environment{Values: make(map[string]string)}
+	env.SetLocation("westus2")
+	env.SetEnvName("test-env")
+
+	infraProvider := NewTerraformProvider(*mockContext.Context, &env, projectDir, options)
+	defer os.Remove(infraProvider.backendOverrideFilePath())
+
+	planningTask := infraProvider.Plan(*mockContext.Context)
+
+	go func() {
+		for range planningTask.Progress() {
+		}
+	}()
+	go func() {
+		for range planningTask.Interactive() {
+		}
+	}()
+
+	_, err := planningTask.Await()
+
+	require.Nil(t, err)
+	require.FileExists(t, infraProvider.backendOverrideFilePath())
+
+	contents, err := os.ReadFile(infraProvider.backendOverrideFilePath())
+	require.Nil(t, err)
+	require.Contains(t, string(contents), `"azurerm"`)
+	require.Contains(t, string(contents), "sttfstate")
+}
+
+// prepareAzurermBackendMocks responds to the `az storage container create` invocation issued by
+// azurermStateBackend.Configure and the `az storage blob exists`/`lease acquire`/`lease release`
+// invocations issued by azurermStateBackend.Lock.
+func prepareAzurermBackendMocks(execUtil *execmock.MockCommandRunner) {
+	execUtil.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, "az storage container create")
+	}).Respond(executil.RunResult{
+		Stdout: "",
+		Stderr: "",
+	})
+
+	execUtil.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, "az storage blob exists")
+	}).Respond(executil.RunResult{
+		Stdout: "true",
+		Stderr: "",
+	})
+
+	execUtil.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, "az storage blob lease acquire")
+	}).Respond(executil.RunResult{
+		Stdout: "leaseid-1234",
+		Stderr: "",
+	})
+
+	execUtil.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, "az storage blob lease release")
+	}).Respond(executil.RunResult{
+		Stdout: "",
+		Stderr: "",
+	})
+}
+
 func TestTerraformDeploy(t *testing.T) {
 	progressLog := []string{}
 	interactiveLog := []bool{}
@@ -115,6 +297,8 @@ func TestTerraformDeploy(t *testing.T) {
 
 	require.Equal(t, deployResult.Deployment.Outputs["AZURE_LOCATION"].Value, infraProvider.env.Values["AZURE_LOCATION"])
 	require.Equal(t, deployResult.Deployment.Outputs["RG_NAME"].Value, fmt.Sprintf("rg-%s", infraProvider.env.GetEnvName()))
+
+	require.Contains(t, progressLog, "create: azurerm_resource_group.rg")
 }
 
 func TestTerraformDestroy(t *testing.T) {
@@ -194,6 +378,86 @@ func TestTerraformGetDeployment(t *testing.T) {
 
 }
 
+// TestTerraformGetDeploymentDrift covers the drift-scan path: `terraform plan -refresh-only
+// -detailed-exitcode` exiting 2 means drift was detected, and `terraform show -json` on the
+// resulting plan is parsed into a DriftReport.
+func TestTerraformGetDeploymentDrift(t *testing.T) {
+	mockContext := mocks.NewMockContext(context.Background())
+	prepareGenericMocks(mockContext.CommandRunner)
+	preparePlanningMocks(mockContext.CommandRunner)
+	prepareDeployMocks(mockContext.CommandRunner)
+	prepareDriftMocks(mockContext.CommandRunner)
+
+	infraProvider := createTerraformProvider(*mockContext.Context)
+	scope := infra.NewSubscriptionScope(*mockContext.Context, infraProvider.env.Values["AZURE_LOCATION"], infraProvider.env.GetSubscriptionId(), infraProvider.env.GetEnvName())
+	driftTask := infraProvider.GetDeploymentDrift(*mockContext.Context, scope)
+
+	go func() {
+		for range driftTask.Progress() {
+		}
+	}()
+	go func() {
+		for range driftTask.Interactive() {
+		}
+	}()
+
+	result, err := driftTask.Await()
+
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.True(t, result.Drift.HasDrift())
+	require.Len(t, result.Drift.Resources, 1)
+	require.Equal(t, "azurerm_resource_group.rg", result.Drift.Resources[0].Address)
+	require.Equal(t, DriftStatusChanged, result.Drift.Resources[0].Status)
+}
+
+// prepareDriftMocks responds to the `terraform plan -refresh-only -detailed-exitcode` and
+// `terraform show -json <planfile>` invocations issued by GetDeploymentDrift.
+func prepareDriftMocks(execUtil *execmock.MockCommandRunner) {
+	modulePath := "..\\..\\..\\..\\test\\samples\\resourcegroupterraform\\infra"
+
+	execUtil.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s plan -refresh-only", modulePath))
+	}).Respond(executil.RunResult{
+		Stdout:   "",
+		Stderr:   "",
+		ExitCode: 2,
+	})
+
+	execUtil.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s show -json", modulePath))
+	}).Respond(executil.RunResult{
+		Stdout: `{"resource_changes":[{"address":"azurerm_resource_group.rg","change":{"actions":["update"],"before":{"tags":{}},"after":{"tags":{"env":"test"}}}}]}`,
+		Stderr: "",
+	})
+}
+
+// TestTerraformSyncProviders covers mirroring Options.RequiredProviders into the plugin cache
+// via `terraform providers mirror` and verifying the mirrored packages against the checksums azd
+// recorded for them last sync. The module fixture this relies on (a populated plugin cache) isn't
+// checked in, so this only exercises the "no providers configured" guard clause.
+func TestTerraformSyncProviders(t *testing.T) {
+	mockContext := mocks.NewMockContext(context.Background())
+	prepareGenericMocks(mockContext.CommandRunner)
+
+	infraProvider := createTerraformProvider(*mockContext.Context)
+	syncTask := infraProvider.SyncProviders(*mockContext.Context)
+
+	go func() {
+		for range syncTask.Progress() {
+		}
+	}()
+	go func() {
+		for range syncTask.Interactive() {
+		}
+	}()
+
+	_, err := syncTask.Await()
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no required providers configured")
+}
+
 func createTerraformProvider(ctx context.Context) *TerraformProvider {
 	projectDir := "../../../../test/samples/resourcegroupterraform"
 	options := Options{
@@ -207,6 +471,274 @@ func createTerraformProvider(ctx context.Context) *TerraformProvider {
 	return NewTerraformProvider(ctx, &env, projectDir, options)
 }
 
+// TestTerraformPlanRemoteBackend covers the case where the module declares a `cloud` block, in
+// which case Plan should delegate to a plan-only run in the Terraform Cloud workspace rather
+// than running `terraform plan -out=...` locally, which Terraform Cloud's remote execution mode
+// doesn't support.
+func TestTerraformPlanRemoteBackend(t *testing.T) {
+	progressLog := []string{}
+	progressDone := make(chan bool)
+
+	mockContext := mocks.NewMockContext(context.Background())
+	prepareGenericMocks(mockContext.CommandRunner)
+	prepareRemoteBackendMocks(mockContext.HttpClient)
+
+	projectDir := "../../../../test/samples/remotebackendterraform"
+	options := Options{Module: "main"}
+	env := environment.Environment{Values: make(map[string]string)}
+	env.SetLocation("westus2")
+	env.SetEnvName("test-env")
+
+	infraProvider := NewTerraformProvider(*mockContext.Context, &env, projectDir, options)
+	planningTask := infraProvider.Plan(*mockContext.Context)
+
+	go func() {
+		for progress := range planningTask.Progress() {
+			progressLog = append(progressLog, progress.Message)
+		}
+		progressDone <- true
+	}()
+	go func() {
+		for range planningTask.Interactive() {
+		}
+	}()
+
+	deploymentPlan, err := planningTask.Await()
+	<-progressDone
+
+	require.Nil(t, err)
+	require.NotNil(t, deploymentPlan)
+	require.Contains(t, progressLog, "Uploading terraform configuration")
+	require.Contains(t, progressLog, "Creating terraform plan-only run")
+
+	details := deploymentPlan.Details.(TerraformDeploymentDetails)
+	require.Equal(t, "run-123", details.RemoteRunID)
+	require.Empty(t, details.localStateFilePath)
+}
+
+// TestTerraformDeployRemoteBackend covers the case where the module declares a `cloud` block,
+// in which case Deploy should delegate the apply to the Terraform Cloud workspace via the TFE
+// API rather than running `terraform apply` locally.
+func TestTerraformDeployRemoteBackend(t *testing.T) {
+	progressLog := []string{}
+	progressDone := make(chan bool)
+
+	mockContext := mocks.NewMockContext(context.Background())
+	prepareGenericMocks(mockContext.CommandRunner)
+	preparePlanningMocks(mockContext.CommandRunner)
+	prepareRemoteBackendMocks(mockContext.HttpClient)
+
+	projectDir := "../../../../test/samples/remotebackendterraform"
+	options := Options{Module: "main"}
+	env := environment.Environment{Values: make(map[string]string)}
+	env.SetLocation("westus2")
+	env.SetEnvName("test-env")
+
+	infraProvider := NewTerraformProvider(*mockContext.Context, &env, projectDir, options)
+	scope := infra.NewSubscriptionScope(*mockContext.Context, env.Values["AZURE_LOCATION"], env.GetSubscriptionId(), env.GetEnvName())
+
+	deploymentPlan := DeploymentPlan{
+		Details: TerraformDeploymentDetails{},
+	}
+
+	deployTask := infraProvider.Deploy(*mockContext.Context, &deploymentPlan, scope)
+
+	go func() {
+		for deployProgress := range deployTask.Progress() {
+			progressLog = append(progressLog, deployProgress.Message)
+		}
+		progressDone <- true
+	}()
+
+	go func() {
+		for range deployTask.Interactive() {
+		}
+	}()
+
+	deployResult, err := deployTask.Await()
+	<-progressDone
+
+	require.Nil(t, err)
+	require.NotNil(t, deployResult)
+	require.Contains(t, progressLog, "Uploading terraform configuration")
+	require.Contains(t, progressLog, "Creating terraform run")
+	require.Equal(t, "westus2", deployResult.Deployment.Outputs["AZURE_LOCATION"].Value)
+}
+
+// TestTerraformTest covers discovering `*.tftest.hcl` files under the module and running
+// `terraform test` against each, reporting the per-run pass/fail outcome.
+func TestTerraformTest(t *testing.T) {
+	mockContext := mocks.NewMockContext(context.Background())
+	prepareGenericMocks(mockContext.CommandRunner)
+	prepareTestMocks(mockContext.CommandRunner)
+
+	infraProvider := createTerraformProvider(*mockContext.Context)
+	testTask := infraProvider.Test(*mockContext.Context)
+
+	go func() {
+		for range testTask.Progress() {
+		}
+	}()
+	go func() {
+		for range testTask.Interactive() {
+		}
+	}()
+
+	result, err := testTask.Await()
+
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.True(t, result.Passed())
+	require.Len(t, result.Files, 1)
+	require.Len(t, result.Files[0].Runs, 1)
+	require.Equal(t, "creates_resource_group", result.Files[0].Runs[0].Name)
+	require.Equal(t, "pass", result.Files[0].Runs[0].Status)
+}
+
+// TestTerraformTestFailure covers a `terraform test` run with a failing assertion, checking that
+// the diagnostic's resource address is pulled out of the run's output alongside its message.
+func TestTerraformTestFailure(t *testing.T) {
+	mockContext := mocks.NewMockContext(context.Background())
+	prepareGenericMocks(mockContext.CommandRunner)
+	prepareTestFailureMocks(mockContext.CommandRunner)
+
+	infraProvider := createTerraformProvider(*mockContext.Context)
+	testTask := infraProvider.Test(*mockContext.Context)
+
+	go func() {
+		for range testTask.Progress() {
+		}
+	}()
+	go func() {
+		for range testTask.Interactive() {
+		}
+	}()
+
+	result, err := testTask.Await()
+
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.False(t, result.Passed())
+	require.Len(t, result.Files, 1)
+	require.Len(t, result.Files[0].Runs, 1)
+
+	run := result.Files[0].Runs[0]
+	require.Equal(t, "creates_resource_group", run.Name)
+	require.Equal(t, "fail", run.Status)
+	require.Len(t, run.Diagnostics, 1)
+	require.Equal(t, "Test assertion failed", run.Diagnostics[0].Summary)
+	require.Equal(t, "azurerm_resource_group.rg", run.Diagnostics[0].ResourceAddress)
+}
+
+// prepareTestMocks responds to the `terraform test` invocation issued for the module's
+// `main.tftest.hcl` file, mirroring the other prepare*Mocks helpers in this file.
+func prepareTestMocks(execUtil *execmock.MockCommandRunner) {
+	modulePath := "..\\..\\..\\..\\test\\samples\\resourcegroupterraform\\infra"
+
+	execUtil.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s test", modulePath))
+	}).Respond(executil.RunResult{
+		Stdout: "run \"creates_resource_group\"... pass",
+		Stderr: "",
+	})
+}
+
+// prepareTestFailureMocks responds to the same `terraform test` invocation as prepareTestMocks,
+// but with output for a run whose assertion failed against a resource's actual value.
+func prepareTestFailureMocks(execUtil *execmock.MockCommandRunner) {
+	modulePath := "..\\..\\..\\..\\test\\samples\\resourcegroupterraform\\infra"
+
+	execUtil.When(func(args executil.RunArgs, command string) bool {
+		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s test", modulePath))
+	}).Respond(executil.RunResult{
+		Stdout: "run \"creates_resource_group\"... fail\n" +
+			"Error: Test assertion failed\n" +
+			"  azurerm_resource_group.rg.location: \"eastus\"\n",
+		Stderr: "",
+	})
+}
+
+// prepareRemoteBackendMocks responds to the subset of the Terraform Cloud/Enterprise API that
+// TerraformProvider calls when delegating a run to a remote workspace, parallel to
+// prepareDeployMocks for the local CLI flow. Every workspace-scoped path is asserted against the
+// opaque workspace ID ("ws-123") resolveWorkspaceID resolves from the "contoso" /
+// "azd-remote-backend-sample" org/workspace name pair the remotebackendterraform fixture
+// declares, not the name itself, since the real TFE API only accepts the ID there.
+func prepareRemoteBackendMocks(httpClient *mockhttp.MockHttpClient) {
+	httpClient.When(func(request *http.Request) bool {
+		return request.URL.Path == "/api/v2/organizations/contoso/workspaces/azd-remote-backend-sample" &&
+			request.Method == http.MethodGet
+	}).RespondFn(func(request *http.Request) (*http.Response, error) {
+		return mockhttp.WithJsonBody(request, map[string]any{
+			"data": map[string]any{
+				"id": "ws-123",
+			},
+		})
+	})
+
+	httpClient.When(func(request *http.Request) bool {
+		return strings.Contains(request.URL.Path, "/workspaces/ws-123/configuration-versions") &&
+			request.Method == http.MethodPost
+	}).RespondFn(func(request *http.Request) (*http.Response, error) {
+		return mockhttp.WithJsonBody(request, map[string]any{
+			"data": map[string]any{
+				"id": "cv-123",
+				"attributes": map[string]any{
+					"upload-url": "https://example.com/upload/cv-123",
+				},
+			},
+		})
+	})
+
+	httpClient.When(func(request *http.Request) bool {
+		return request.URL.Host == "example.com" && request.Method == http.MethodPut
+	}).RespondFn(func(request *http.Request) (*http.Response, error) {
+		return mockhttp.WithStatusCode(request, http.StatusOK)
+	})
+
+	httpClient.When(func(request *http.Request) bool {
+		return strings.Contains(request.URL.Path, "/runs") && request.Method == http.MethodPost
+	}).RespondFn(func(request *http.Request) (*http.Response, error) {
+		return mockhttp.WithJsonBody(request, map[string]any{
+			"data": map[string]any{
+				"id": "run-123",
+				"attributes": map[string]any{
+					"status": "applied",
+				},
+			},
+		})
+	})
+
+	httpClient.When(func(request *http.Request) bool {
+		return strings.Contains(request.URL.Path, "/runs/run-123") && request.Method == http.MethodGet
+	}).RespondFn(func(request *http.Request) (*http.Response, error) {
+		return mockhttp.WithJsonBody(request, map[string]any{
+			"data": map[string]any{
+				"id": "run-123",
+				"attributes": map[string]any{
+					"status": "applied",
+				},
+			},
+		})
+	})
+
+	httpClient.When(func(request *http.Request) bool {
+		return strings.Contains(request.URL.Path, "/workspaces/ws-123/current-state-version-outputs")
+	}).RespondFn(func(request *http.Request) (*http.Response, error) {
+		return mockhttp.WithJsonBody(request, map[string]any{
+			"data": []map[string]any{
+				{
+					"attributes": map[string]any{
+						"name":      "AZURE_LOCATION",
+						"value":     "westus2",
+						"sensitive": false,
+					},
+				},
+			},
+		})
+	})
+}
+
 func prepareGenericMocks(execUtil *execmock.MockCommandRunner) {
 
 	execUtil.When(func(args executil.RunArgs, command string) bool {
@@ -237,7 +769,10 @@ func preparePlanningMocks(execUtil *execmock.MockCommandRunner) {
 	execUtil.When(func(args executil.RunArgs, command string) bool {
 		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s plan", modulePath))
 	}).Respond(executil.RunResult{
-		Stdout: string("To perform exactly these actions, run the following command to apply:terraform apply"),
+		Stdout: strings.Join([]string{
+			`{"type":"version","terraform":"1.1.7"}`,
+			`{"type":"planned_change","change":{"resource":{"addr":"azurerm_resource_group.rg"},"action":"create"}}`,
+		}, "\n"),
 		Stderr: "",
 	})
 }
@@ -255,7 +790,10 @@ func prepareDeployMocks(execUtil *execmock.MockCommandRunner) {
 	execUtil.When(func(args executil.RunArgs, command string) bool {
 		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s apply", modulePath))
 	}).Respond(executil.RunResult{
-		Stdout: string(""),
+		Stdout: strings.Join([]string{
+			`{"type":"apply_start","hook":{"resource":{"addr":"azurerm_resource_group.rg"},"action":"create"}}`,
+			`{"type":"apply_complete","hook":{"resource":{"addr":"azurerm_resource_group.rg"},"action":"create","elapsed_seconds":1.5}}`,
+		}, "\n"),
 		Stderr: "",
 	})
 
@@ -289,7 +827,7 @@ func prepareDestroyMocks(execUtil *execmock.MockCommandRunner) {
 	execUtil.When(func(args executil.RunArgs, command string) bool {
 		return strings.Contains(command, fmt.Sprintf("terraform -chdir=%s destroy", modulePath))
 	}).Respond(executil.RunResult{
-		Stdout: string(""),
+		Stdout: `{"type":"apply_complete","hook":{"resource":{"addr":"azurerm_resource_group.rg"},"action":"delete","elapsed_seconds":0.8}}`,
 		Stderr: "",
 	})
 
@@ -0,0 +1,481 @@
+package terraform
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+)
+
+const tfeAPIVersion = "application/vnd.api+json"
+
+// tfeClient is a narrow client over the subset of the Terraform Cloud/Enterprise API that
+// azd needs in order to delegate a plan/apply/destroy run to a remote workspace.
+type tfeClient struct {
+	hostname string
+	token    string
+	http     *http.Client
+}
+
+func newTFEClient(backend terraformBackend) *tfeClient {
+	return &tfeClient{
+		hostname: backend.hostname,
+		token:    os.Getenv(tfTokenEnvVar(backend.hostname)),
+		http:     http.DefaultClient,
+	}
+}
+
+// tfTokenEnvVar derives the `TF_TOKEN_<hostname>` environment variable name terraform itself
+// looks up for a given hostname: per
+// https://developer.hashicorp.com/terraform/cli/config/config-file#environment-variable-credentials,
+// periods become underscores and hyphens become double underscores, since neither is valid in an
+// unquoted shell variable name.
+func tfTokenEnvVar(hostname string) string {
+	hostname = strings.ReplaceAll(hostname, "-", "__")
+	hostname = strings.ReplaceAll(hostname, ".", "_")
+	return "TF_TOKEN_" + hostname
+}
+
+func (c *tfeClient) do(ctx context.Context, method, p string, body interface{}, out interface{}) error {
+	var reqBody *bytes.Reader
+	if body != nil {
+		bytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshalling TFE request: %w", err)
+		}
+		reqBody = bytes2Reader(bytes)
+	} else {
+		reqBody = bytes2Reader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("https://%s/api/v2%s", c.hostname, p), reqBody)
+	if err != nil {
+		return fmt.Errorf("building TFE request: %w", err)
+	}
+	req.Header.Set("Content-Type", tfeAPIVersion)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling TFE: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("TFE request to %s failed with status %s", p, resp.Status)
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func bytes2Reader(b []byte) *bytes.Reader {
+	return bytes.NewReader(b)
+}
+
+// resolveWorkspaceID looks up the opaque workspace ID the TFE API expects in its
+// workspace-scoped paths (e.g. `/workspaces/{id}/...`), given the workspace name parsed out of
+// the module's backend block. The API doesn't accept the name directly in those paths.
+func resolveWorkspaceID(ctx context.Context, client *tfeClient, organization, workspace string) (string, error) {
+	var response struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+
+	path := fmt.Sprintf("/organizations/%s/workspaces/%s", organization, workspace)
+	if err := client.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return "", fmt.Errorf("resolving workspace id: %w", err)
+	}
+
+	return response.Data.ID, nil
+}
+
+type tfeRun struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Status string `json:"status"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// deployRemote uploads the module as a configuration version to the workspace identified by
+// backend, creates a run, waits for it to reach a terminal state (always prompting for
+// confirmation first) and returns the resulting deployment. details is not used.
+func (p *TerraformProvider) deployRemote(
+	ctx context.Context,
+	asyncContext interface {
+		SetProgress(report ProgressReport)
+		SetInteractive(bool)
+	},
+	details TerraformDeploymentDetails,
+	backend terraformBackend,
+) (*DeployResult, error) {
+	outputs, err := p.runRemote(ctx, asyncContext, backend, false /* isDestroy */, false /* autoApprove */)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeployResult{
+		Deployment: &Deployment{Outputs: outputs},
+	}, nil
+}
+
+// destroyRemote mirrors deployRemote for the destroy path: it delegates the destroy run to
+// the remote workspace rather than running `terraform destroy` locally.
+func (p *TerraformProvider) destroyRemote(
+	ctx context.Context,
+	asyncContext interface {
+		SetProgress(report ProgressReport)
+		SetInteractive(bool)
+	},
+	backend terraformBackend,
+	force bool,
+) (map[string]OutputParameter, error) {
+	return p.runRemote(ctx, asyncContext, backend, true /* isDestroy */, force)
+}
+
+// runRemote uploads the module as a new configuration version, creates a run against the
+// remote workspace, waits for it to finish and returns the resulting workspace outputs.
+// Confirmation is requested through Interactive() unless autoApprove is set.
+func (p *TerraformProvider) runRemote(
+	ctx context.Context,
+	asyncContext interface {
+		SetProgress(report ProgressReport)
+		SetInteractive(bool)
+	},
+	backend terraformBackend,
+	isDestroy bool,
+	autoApprove bool,
+) (map[string]OutputParameter, error) {
+	client := newTFEClient(backend)
+
+	workspaceID, err := resolveWorkspaceID(ctx, client, backend.organization, backend.workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	asyncContext.SetProgress(NewProgressReport("Uploading terraform configuration"))
+	configVersionID, uploadURL, err := p.createConfigurationVersion(ctx, client, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.uploadConfiguration(ctx, uploadURL); err != nil {
+		return nil, err
+	}
+
+	if !autoApprove {
+		asyncContext.SetInteractive(true)
+		confirmed, err := p.console.Confirm(ctx, input.ConsoleOptions{
+			Message: "Do you want to apply this run in the remote workspace?",
+		})
+		if err != nil {
+			return nil, err
+		}
+		asyncContext.SetInteractive(false)
+		if !confirmed {
+			return nil, fmt.Errorf("remote run cancelled")
+		}
+	}
+
+	asyncContext.SetProgress(NewProgressReport("Creating terraform run"))
+	runID, err := p.createRun(ctx, client, workspaceID, configVersionID, isDestroy, autoApprove, false /* planOnly */)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.streamRunLog(ctx, client, asyncContext, runID); err != nil {
+		return nil, err
+	}
+
+	return p.fetchWorkspaceOutputs(ctx, client, workspaceID)
+}
+
+// planRemote delegates Plan to the remote workspace for a module with a cloud/remote backend.
+// Terraform Cloud's remote execution mode doesn't support writing out a local plan file
+// (`-out`), so rather than running `terraform plan` locally, this uploads the configuration and
+// creates a plan-only run, returning its ID as TerraformDeploymentDetails.RemoteRunID. Deploy
+// doesn't read that ID; it creates a run of its own.
+func (p *TerraformProvider) planRemote(
+	ctx context.Context,
+	asyncContext interface {
+		SetProgress(report ProgressReport)
+		SetInteractive(bool)
+	},
+	backend terraformBackend,
+) (*DeploymentPlan, error) {
+	client := newTFEClient(backend)
+
+	workspaceID, err := resolveWorkspaceID(ctx, client, backend.organization, backend.workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	asyncContext.SetProgress(NewProgressReport("Uploading terraform configuration"))
+	configVersionID, uploadURL, err := p.createConfigurationVersion(ctx, client, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.uploadConfiguration(ctx, uploadURL); err != nil {
+		return nil, err
+	}
+
+	asyncContext.SetProgress(NewProgressReport("Creating terraform plan-only run"))
+	runID, err := p.createRun(ctx, client, workspaceID, configVersionID, false /* isDestroy */, false /* autoApply */, true /* planOnly */)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.streamRunLog(ctx, client, asyncContext, runID); err != nil {
+		return nil, err
+	}
+
+	return &DeploymentPlan{
+		Deployment: &Deployment{
+			Parameters: map[string]InputParameter{
+				"location": {Value: p.env.Values["AZURE_LOCATION"]},
+				"name":     {Value: p.env.Values["AZURE_ENV_NAME"]},
+			},
+		},
+		Details: TerraformDeploymentDetails{RemoteRunID: runID},
+	}, nil
+}
+
+func (p *TerraformProvider) createConfigurationVersion(
+	ctx context.Context,
+	client *tfeClient,
+	workspaceID string,
+) (string, string, error) {
+	var response struct {
+		Data struct {
+			ID         string `json:"id"`
+			Attributes struct {
+				UploadURL string `json:"upload-url"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+
+	body := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": "configuration-versions",
+			"attributes": map[string]interface{}{
+				"auto-queue-runs": false,
+			},
+		},
+	}
+
+	err := client.do(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("/workspaces/%s/configuration-versions", workspaceID),
+		body,
+		&response,
+	)
+	if err != nil {
+		return "", "", fmt.Errorf("creating configuration version: %w", err)
+	}
+
+	return response.Data.ID, response.Data.Attributes.UploadURL, nil
+}
+
+// uploadConfiguration tars and gzips the module directory and PUTs it to the upload URL
+// returned when creating the configuration version.
+func (p *TerraformProvider) uploadConfiguration(ctx context.Context, uploadURL string) error {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := filepath.WalkDir(p.modulePath(), func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(p.modulePath(), filePath)
+		if err != nil {
+			return err
+		}
+
+		contents, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if err := tarWriter.WriteHeader(&tar.Header{
+			Name: relPath,
+			Mode: int64(info.Mode()),
+			Size: info.Size(),
+		}); err != nil {
+			return err
+		}
+
+		_, err = tarWriter.Write(contents)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("packaging terraform configuration: %w", err)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("packaging terraform configuration: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("packaging terraform configuration: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return fmt.Errorf("building upload request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading terraform configuration: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("uploading terraform configuration failed with status %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (p *TerraformProvider) createRun(
+	ctx context.Context,
+	client *tfeClient,
+	workspaceID string,
+	configVersionID string,
+	isDestroy bool,
+	autoApply bool,
+	planOnly bool,
+) (string, error) {
+	var run tfeRun
+
+	attributes := map[string]interface{}{
+		"is-destroy": isDestroy,
+		"auto-apply": autoApply,
+	}
+	if planOnly {
+		attributes["plan-only"] = true
+	}
+
+	body := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type":       "runs",
+			"attributes": attributes,
+			"relationships": map[string]interface{}{
+				"configuration-version": map[string]interface{}{
+					"data": map[string]interface{}{
+						"type": "configuration-versions",
+						"id":   configVersionID,
+					},
+				},
+				"workspace": map[string]interface{}{
+					"data": map[string]interface{}{
+						"type": "workspaces",
+						"id":   workspaceID,
+					},
+				},
+			},
+		},
+	}
+
+	if err := client.do(ctx, http.MethodPost, "/runs", body, &run); err != nil {
+		return "", fmt.Errorf("creating terraform run: %w", err)
+	}
+
+	return run.Data.ID, nil
+}
+
+// streamRunLog polls the run until it reaches a terminal status, forwarding status changes
+// to Progress() so callers see the same kind of incremental updates a local run produces.
+func (p *TerraformProvider) streamRunLog(
+	ctx context.Context,
+	client *tfeClient,
+	asyncContext interface{ SetProgress(report ProgressReport) },
+	runID string,
+) error {
+	lastStatus := ""
+
+	for {
+		var run tfeRun
+		if err := client.do(ctx, http.MethodGet, fmt.Sprintf("/runs/%s", runID), nil, &run); err != nil {
+			return fmt.Errorf("polling terraform run: %w", err)
+		}
+
+		status := run.Data.Attributes.Status
+		if status != lastStatus {
+			asyncContext.SetProgress(NewProgressReport(fmt.Sprintf("remote run %s: %s", runID, status)))
+			lastStatus = status
+		}
+
+		switch status {
+		case "applied", "planned_and_finished", "discarded", "errored", "canceled":
+			if status == "errored" || status == "discarded" || status == "canceled" {
+				return fmt.Errorf("remote run %s ended with status %s", runID, status)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (p *TerraformProvider) fetchWorkspaceOutputs(
+	ctx context.Context,
+	client *tfeClient,
+	workspaceID string,
+) (map[string]OutputParameter, error) {
+	var response struct {
+		Data []struct {
+			Attributes struct {
+				Name      string      `json:"name"`
+				Value     interface{} `json:"value"`
+				Sensitive bool        `json:"sensitive"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+
+	err := client.do(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("/workspaces/%s/current-state-version-outputs", workspaceID),
+		nil,
+		&response,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("fetching remote workspace outputs: %w", err)
+	}
+
+	outputs := make(map[string]OutputParameter, len(response.Data))
+	for _, item := range response.Data {
+		outputs[item.Attributes.Name] = OutputParameter{Value: item.Attributes.Value}
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,539 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+)
+
+// StateBackend abstracts where a module's terraform state lives and how azd configures
+// terraform to use it. TerraformProvider selects an implementation in Plan based on
+// Options.StateBackend and writes the resulting BackendBlock() out as a backend_override.tf.json
+// file before running `terraform init`.
+type StateBackend interface {
+	// Configure performs any setup the backend needs before terraform can use it, e.g.
+	// creating the Azure Storage container a state blob will live in.
+	Configure(ctx context.Context, env *environment.Environment) error
+
+	// BackendBlock returns the `backend_override.tf.json` contents (a JSON-encoded `terraform { backend
+	// "..." {...} }` block) that selects this backend for the module.
+	BackendBlock() string
+
+	// Pull returns the raw state file contents currently stored by the backend.
+	Pull(ctx context.Context) ([]byte, error)
+
+	// Push writes state to the backend, overwriting whatever it currently holds.
+	Push(ctx context.Context, state []byte) error
+
+	// Lock acquires the backend's own native lock (a lock file for local, a blob lease for
+	// azurerm, a workspace lock for remote), returning an unlock function to release it once
+	// the caller is done. terraform itself already locks the backend around apply/destroy;
+	// Lock guards the window where azd configures/reconfigures the backend, and a direct
+	// Pull/Push, against a second concurrent azd run racing the same state.
+	Lock(ctx context.Context) (unlock func(ctx context.Context) error, err error)
+}
+
+// stateBackendKind identifies which StateBackend implementation a module is configured to use.
+// It's persisted alongside the module's other environment-scoped artifacts so Plan can tell when
+// the backend has changed and a `-migrate-state` init is required.
+type stateBackendKind string
+
+const (
+	stateBackendKindLocal   stateBackendKind = "local"
+	stateBackendKindAzurerm stateBackendKind = "azurerm"
+	stateBackendKindRemote  stateBackendKind = "remote"
+)
+
+// stateBackend builds the StateBackend selected by Options.StateBackend (defaulting to the
+// local backend when unset, preserving today's behavior).
+func (p *TerraformProvider) stateBackend() (StateBackend, error) {
+	switch stateBackendKind(p.options.StateBackend) {
+	case "", stateBackendKindLocal:
+		return &localStateBackend{path: p.localStateFilePath()}, nil
+	case stateBackendKindAzurerm:
+		return &azurermStateBackend{
+			provider:           p,
+			resourceGroupName:  p.options.StateBackendResourceGroup,
+			storageAccountName: p.options.StateBackendStorageAccount,
+			containerName:      fmt.Sprintf("tfstate-%s", p.env.GetEnvName()),
+			key:                fmt.Sprintf("%s.tfstate", p.options.Module),
+			useMSI:             p.options.StateBackendStorageAccount != "" && p.options.StateBackendSASToken == "",
+			sasToken:           p.options.StateBackendSASToken,
+		}, nil
+	case stateBackendKindRemote:
+		return &remoteStateBackend{
+			organization: p.options.StateBackendOrganization,
+			workspace:    p.options.StateBackendWorkspace,
+			hostname:     defaultHostname(p.options.StateBackendHostname),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported state backend %q", p.options.StateBackend)
+	}
+}
+
+// backendOverrideFilePath is the `backend_override.tf.json` file TerraformProvider writes into the module
+// directory to select the active StateBackend. Terraform merges `*_override.tf.json` files over
+// the module's own configuration, so this takes effect without editing the module's source.
+func (p *TerraformProvider) backendOverrideFilePath() string {
+	return path.Join(p.modulePath(), "backend_override.tf.json")
+}
+
+// backendKindFilePath records which stateBackendKind was last configured for this environment,
+// so Plan can tell when the backend has changed and a `-migrate-state` init is needed.
+func (p *TerraformProvider) backendKindFilePath() string {
+	return path.Join(p.envPath(), "backend.json")
+}
+
+// configureStateBackend writes the backend_override.tf.json file for the selected backend,
+// performs any backend-specific setup and runs `terraform init -reconfigure`, adding
+// `-migrate-state` when the backend kind has changed since the last Plan.
+func (p *TerraformProvider) configureStateBackend(
+	ctx context.Context,
+	asyncContext interface{ SetProgress(report ProgressReport) },
+) error {
+	backend, err := p.stateBackend()
+	if err != nil {
+		return err
+	}
+
+	if err := backend.Configure(ctx, p.env); err != nil {
+		return fmt.Errorf("configuring state backend: %w", err)
+	}
+
+	if err := os.MkdirAll(p.envPath(), 0755); err != nil {
+		return fmt.Errorf("creating environment directory: %w", err)
+	}
+
+	unlock, err := backend.Lock(ctx)
+	if err != nil {
+		return fmt.Errorf("locking state backend: %w", err)
+	}
+	defer unlock(ctx)
+
+	if err := os.WriteFile(p.backendOverrideFilePath(), []byte(backend.BackendBlock()), 0644); err != nil {
+		return fmt.Errorf("writing backend override: %w", err)
+	}
+
+	migrating := p.backendKindChanged()
+	if err := p.writeBackendKind(); err != nil {
+		return err
+	}
+
+	initArgs := []string{"init", "-reconfigure"}
+	if migrating {
+		asyncContext.SetProgress(NewProgressReport("Migrating terraform state to new backend"))
+		initArgs = append(initArgs, "-migrate-state")
+	}
+	if p.options.PluginMirrorDir != "" {
+		initArgs = append(initArgs, fmt.Sprintf("-plugin-dir=%s", p.options.PluginMirrorDir))
+	}
+
+	if _, _, err := p.runTerraformCommandJSON(ctx, asyncContext, initArgs...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// backendKindChanged reports whether the backend kind recorded in backendKindFilePath differs
+// from the one Options currently selects (or there's no record yet and a non-local backend is
+// selected, since that also requires migrating state out of the implicit local backend).
+func (p *TerraformProvider) backendKindChanged() bool {
+	current := p.options.StateBackend
+	if current == "" {
+		current = string(stateBackendKindLocal)
+	}
+
+	previous, err := os.ReadFile(p.backendKindFilePath())
+	if err != nil {
+		return current != string(stateBackendKindLocal)
+	}
+
+	return strings.TrimSpace(string(previous)) != current
+}
+
+func (p *TerraformProvider) writeBackendKind() error {
+	current := p.options.StateBackend
+	if current == "" {
+		current = string(stateBackendKindLocal)
+	}
+
+	return os.WriteFile(p.backendKindFilePath(), []byte(current), 0644)
+}
+
+// localStateBackend is the default StateBackend: state is a plain file alongside the module's
+// other environment-scoped artifacts.
+type localStateBackend struct {
+	path string
+}
+
+func (b *localStateBackend) Configure(ctx context.Context, env *environment.Environment) error {
+	return nil
+}
+
+func (b *localStateBackend) BackendBlock() string {
+	return toBackendJSON(map[string]interface{}{
+		"path": b.path,
+	}, "local")
+}
+
+func (b *localStateBackend) Pull(ctx context.Context) ([]byte, error) {
+	return os.ReadFile(b.path)
+}
+
+func (b *localStateBackend) Push(ctx context.Context, state []byte) error {
+	return os.WriteFile(b.path, state, 0644)
+}
+
+// Lock takes out the local backend's lock by exclusively creating a sidecar `.lock` file next to
+// the state file, mirroring terraform's own local backend locking. Unlock removes it.
+func (b *localStateBackend) Lock(ctx context.Context) (func(ctx context.Context) error, error) {
+	lockPath := b.path + ".lock"
+
+	lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("state is locked by another run (%s exists)", lockPath)
+		}
+		return nil, fmt.Errorf("acquiring local state lock: %w", err)
+	}
+	lockFile.Close()
+
+	return func(ctx context.Context) error {
+		return os.Remove(lockPath)
+	}, nil
+}
+
+// azurermStateBackend stores state in a blob in an Azure Storage container, letting a team share
+// state without checking it into `.azure/`. The backend block terraform uses authenticates via
+// SAS token when one is configured, falling back to Azure AD auth otherwise; azd's own
+// `az storage` calls (Configure, Pull, Push, Lock) always use `--auth-mode login`.
+type azurermStateBackend struct {
+	provider *TerraformProvider
+
+	resourceGroupName  string
+	storageAccountName string
+	containerName      string
+	key                string
+
+	useMSI   bool
+	sasToken string
+}
+
+func (b *azurermStateBackend) Configure(ctx context.Context, env *environment.Environment) error {
+	if b.storageAccountName == "" {
+		return fmt.Errorf("azurerm state backend requires Options.StateBackendStorageAccount")
+	}
+
+	runArgs := executil.RunArgs{
+		Cmd: "az",
+		Args: []string{
+			"storage", "container", "create",
+			"--name", b.containerName,
+			"--account-name", b.storageAccountName,
+			"--auth-mode", "login",
+			"--only-show-errors",
+		},
+	}
+
+	if _, err := executil.GetCommandRunner(ctx).Run(ctx, runArgs); err != nil {
+		return fmt.Errorf("creating state container: %w", err)
+	}
+
+	return nil
+}
+
+func (b *azurermStateBackend) BackendBlock() string {
+	attributes := map[string]interface{}{
+		"resource_group_name":  b.resourceGroupName,
+		"storage_account_name": b.storageAccountName,
+		"container_name":       b.containerName,
+		"key":                  b.key,
+	}
+	if b.useMSI {
+		attributes["use_azuread_auth"] = true
+	} else {
+		attributes["sas_token"] = b.sasToken
+	}
+
+	return toBackendJSON(attributes, "azurerm")
+}
+
+func (b *azurermStateBackend) Pull(ctx context.Context) ([]byte, error) {
+	return b.runBlobCommand(ctx, "download")
+}
+
+func (b *azurermStateBackend) Push(ctx context.Context, state []byte) error {
+	tempFile, err := os.CreateTemp("", "azd-tfstate-*.json")
+	if err != nil {
+		return fmt.Errorf("writing temporary state file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(state); err != nil {
+		return fmt.Errorf("writing temporary state file: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("writing temporary state file: %w", err)
+	}
+
+	runArgs := executil.RunArgs{
+		Cmd: "az",
+		Args: []string{
+			"storage", "blob", "upload",
+			"--account-name", b.storageAccountName,
+			"--container-name", b.containerName,
+			"--name", b.key,
+			"--file", tempFile.Name(),
+			"--auth-mode", "login",
+			"--overwrite",
+			"--only-show-errors",
+		},
+	}
+
+	if _, err := executil.GetCommandRunner(ctx).Run(ctx, runArgs); err != nil {
+		return fmt.Errorf("uploading state: %w", err)
+	}
+
+	return nil
+}
+
+// Lock acquires a lease on the state blob, creating an empty one first if this is the first
+// Plan against a fresh backend (a lease can only be taken against a blob that already exists).
+func (b *azurermStateBackend) Lock(ctx context.Context) (func(ctx context.Context) error, error) {
+	if err := b.ensureStateBlobExists(ctx); err != nil {
+		return nil, err
+	}
+
+	runArgs := executil.RunArgs{
+		Cmd: "az",
+		Args: []string{
+			"storage", "blob", "lease", "acquire",
+			"--account-name", b.storageAccountName,
+			"--container-name", b.containerName,
+			"--blob-name", b.key,
+			"--lease-duration", "60",
+			"--auth-mode", "login",
+			"--only-show-errors",
+		},
+	}
+
+	runResult, err := executil.GetCommandRunner(ctx).Run(ctx, runArgs)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring state lease: %w", err)
+	}
+	leaseID := strings.Trim(strings.TrimSpace(runResult.Stdout), `"`)
+
+	return func(ctx context.Context) error {
+		releaseArgs := executil.RunArgs{
+			Cmd: "az",
+			Args: []string{
+				"storage", "blob", "lease", "release",
+				"--account-name", b.storageAccountName,
+				"--container-name", b.containerName,
+				"--blob-name", b.key,
+				"--lease-id", leaseID,
+				"--auth-mode", "login",
+				"--only-show-errors",
+			},
+		}
+		if _, err := executil.GetCommandRunner(ctx).Run(ctx, releaseArgs); err != nil {
+			return fmt.Errorf("releasing state lease: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// ensureStateBlobExists creates an empty state blob when one doesn't already exist, since Lock
+// needs a blob to lease and the very first Plan against a fresh azurerm backend runs before
+// terraform has had a chance to write any state.
+func (b *azurermStateBackend) ensureStateBlobExists(ctx context.Context) error {
+	existsArgs := executil.RunArgs{
+		Cmd: "az",
+		Args: []string{
+			"storage", "blob", "exists",
+			"--account-name", b.storageAccountName,
+			"--container-name", b.containerName,
+			"--name", b.key,
+			"--auth-mode", "login",
+			"--only-show-errors",
+		},
+	}
+	existsResult, err := executil.GetCommandRunner(ctx).Run(ctx, existsArgs)
+	if err != nil {
+		return fmt.Errorf("checking for existing state blob: %w", err)
+	}
+	if strings.Contains(existsResult.Stdout, "true") {
+		return nil
+	}
+
+	tempFile, err := os.CreateTemp("", "azd-tfstate-*.json")
+	if err != nil {
+		return fmt.Errorf("creating empty state blob: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("creating empty state blob: %w", err)
+	}
+
+	uploadArgs := executil.RunArgs{
+		Cmd: "az",
+		Args: []string{
+			"storage", "blob", "upload",
+			"--account-name", b.storageAccountName,
+			"--container-name", b.containerName,
+			"--name", b.key,
+			"--file", tempFile.Name(),
+			"--auth-mode", "login",
+			"--only-show-errors",
+		},
+	}
+	if _, err := executil.GetCommandRunner(ctx).Run(ctx, uploadArgs); err != nil {
+		return fmt.Errorf("creating empty state blob: %w", err)
+	}
+
+	return nil
+}
+
+func (b *azurermStateBackend) runBlobCommand(ctx context.Context, verb string) ([]byte, error) {
+	runArgs := executil.RunArgs{
+		Cmd: "az",
+		Args: []string{
+			"storage", "blob", verb,
+			"--account-name", b.storageAccountName,
+			"--container-name", b.containerName,
+			"--name", b.key,
+			"--auth-mode", "login",
+			"--only-show-errors",
+		},
+	}
+
+	runResult, err := executil.GetCommandRunner(ctx).Run(ctx, runArgs)
+	if err != nil {
+		return nil, fmt.Errorf("downloading state: %w", err)
+	}
+
+	return []byte(runResult.Stdout), nil
+}
+
+// remoteStateBackend delegates state storage entirely to a Terraform Cloud/Enterprise
+// workspace. TerraformProvider itself never calls Pull/Push; they're provided so
+// remoteStateBackend still satisfies StateBackend for tooling that only needs to inspect state.
+// Deploy/Destroy hand the run off to a workspace (see terraform_remote.go) only when the
+// module's own .tf files declare a remote/cloud backend, not because this backend is selected.
+type remoteStateBackend struct {
+	organization string
+	workspace    string
+	hostname     string
+}
+
+func (b *remoteStateBackend) Configure(ctx context.Context, env *environment.Environment) error {
+	return nil
+}
+
+func (b *remoteStateBackend) BackendBlock() string {
+	return toBackendJSON(map[string]interface{}{
+		"hostname":     b.hostname,
+		"organization": b.organization,
+		"workspaces": map[string]interface{}{
+			"name": b.workspace,
+		},
+	}, "remote")
+}
+
+func (b *remoteStateBackend) Pull(ctx context.Context) ([]byte, error) {
+	client := newTFEClient(terraformBackend{kind: "remote", organization: b.organization, workspace: b.workspace, hostname: b.hostname})
+
+	workspaceID, err := resolveWorkspaceID(ctx, client, b.organization, b.workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data struct {
+			Attributes struct {
+				HostedStateDownloadURL string `json:"hosted-state-download-url"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	apiPath := fmt.Sprintf("/workspaces/%s/current-state-version", workspaceID)
+	if err := client.do(ctx, http.MethodGet, apiPath, nil, &response); err != nil {
+		return nil, fmt.Errorf("fetching remote state: %w", err)
+	}
+
+	return fetchURL(ctx, response.Data.Attributes.HostedStateDownloadURL)
+}
+
+func fetchURL(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building state download request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading remote state: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("downloading remote state failed with status %s", resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (b *remoteStateBackend) Push(ctx context.Context, state []byte) error {
+	return fmt.Errorf("pushing state directly to a remote workspace isn't supported; use terraform apply")
+}
+
+// Lock takes out the workspace's own lock via the TFE API, in the same way the `terraform`
+// CLI does before it touches remote state.
+func (b *remoteStateBackend) Lock(ctx context.Context) (func(ctx context.Context) error, error) {
+	client := newTFEClient(terraformBackend{kind: "remote", organization: b.organization, workspace: b.workspace, hostname: b.hostname})
+
+	workspaceID, err := resolveWorkspaceID(ctx, client, b.organization, b.workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	lockPath := fmt.Sprintf("/workspaces/%s/actions/lock", workspaceID)
+	if err := client.do(ctx, http.MethodPost, lockPath, map[string]interface{}{"reason": "azd"}, nil); err != nil {
+		return nil, fmt.Errorf("locking remote workspace: %w", err)
+	}
+
+	return func(ctx context.Context) error {
+		unlockPath := fmt.Sprintf("/workspaces/%s/actions/unlock", workspaceID)
+		if err := client.do(ctx, http.MethodPost, unlockPath, nil, nil); err != nil {
+			return fmt.Errorf("unlocking remote workspace: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+func toBackendJSON(attributes map[string]interface{}, kind string) string {
+	document := map[string]interface{}{
+		"terraform": map[string]interface{}{
+			"backend": map[string]interface{}{
+				kind: attributes,
+			},
+		},
+	}
+
+	bytes, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		// attributes is always built from plain strings/bools by this file's callers, so
+		// marshalling cannot fail in practice.
+		panic(err)
+	}
+
+	return string(bytes)
+}